@@ -1,17 +1,41 @@
 package main
 
 import (
+	_ "embed"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gofrs/flock"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
 )
 
+//go:embed schemas/provider.schema.json
+var providerSchemaJSON []byte
+
+//go:embed schemas/settings.schema.json
+var settingsSchemaJSON []byte
+
 const (
-	claudeDir    = ".claude"
-	settingsFile = "settings.json"
+	claudeDir     = ".claude"
+	settingsFile  = "settings.json"
+	backupsDir    = "backups"
+	maxBackups    = 10
+	lockSuffix    = ".lock"
+	tmpSuffix     = ".tmp"
+	stateFile     = ".ccs-state.json"
+	watchDebounce = 300 * time.Millisecond
 )
 
 // Settings represents the Claude settings.json structure
@@ -40,11 +64,11 @@ func (s *Settings) UnmarshalJSON(data []byte) error {
 
 	// Map of known field names to their corresponding field handling
 	knownFields := map[string]func(interface{}){
-		"permissions":     func(v interface{}) { s.Permissions = toMap(v) },
-		"model":           func(v interface{}) { s.Model = toString(v) },
-		"statusLine":      func(v interface{}) { s.StatusLine = toMap(v) },
-		"enabledPlugins":  func(v interface{}) { s.EnabledPlugins = toMap(v) },
-		"env":             func(v interface{}) { s.Env = toMap(v) },
+		"permissions":    func(v interface{}) { s.Permissions = toMap(v) },
+		"model":          func(v interface{}) { s.Model = toString(v) },
+		"statusLine":     func(v interface{}) { s.StatusLine = toMap(v) },
+		"enabledPlugins": func(v interface{}) { s.EnabledPlugins = toMap(v) },
+		"env":            func(v interface{}) { s.Env = toMap(v) },
 	}
 
 	// Process known fields, store unknown fields in Extra
@@ -102,9 +126,70 @@ func toString(v interface{}) string {
 	return ""
 }
 
-// EnvConfig represents the provider-specific env config
-type EnvConfig struct {
-	Env map[string]interface{} `json:"env"`
+// ProviderFile represents the on-disk shape of a provider config. Besides
+// env it may carry Settings overrides (permissions, model, statusLine) and
+// an extends chain of other provider names whose layers are merged first.
+type ProviderFile struct {
+	Extends     []string               `json:"extends,omitempty" yaml:"extends,omitempty" toml:"extends,omitempty"`
+	Env         map[string]interface{} `json:"env,omitempty" yaml:"env,omitempty" toml:"env,omitempty"`
+	Permissions map[string]interface{} `json:"permissions,omitempty" yaml:"permissions,omitempty" toml:"permissions,omitempty"`
+	Model       string                 `json:"model,omitempty" yaml:"model,omitempty" toml:"model,omitempty"`
+	StatusLine  map[string]interface{} `json:"statusLine,omitempty" yaml:"statusLine,omitempty" toml:"statusLine,omitempty"`
+}
+
+// providerExtensions are the file extensions loadProviderEnv recognizes for
+// a provider config, in the order they're tried when resolving a name.
+var providerExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// isProviderExtension reports whether ext is one of providerExtensions.
+func isProviderExtension(ext string) bool {
+	for _, known := range providerExtensions {
+		if ext == known {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveProviderPath finds the on-disk config file for a provider name,
+// trying each of providerExtensions. It errors if none exist, or if more
+// than one extension is present for the same name, since it would be
+// ambiguous which one the user meant.
+func resolveProviderPath(provider string, claudeDir string) (string, error) {
+	var matches []string
+	for _, ext := range providerExtensions {
+		path := filepath.Join(claudeDir, provider+ext)
+		if _, err := os.Stat(path); err == nil {
+			matches = append(matches, path)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no provider config found for %q in %s (tried %s)", provider, claudeDir, strings.Join(providerExtensions, ", "))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous provider config for %q: found %s", provider, strings.Join(matches, ", "))
+	}
+}
+
+// decodeProviderData unmarshals data into out, dispatching on path's
+// extension, so every reader of a provider file agrees on its format.
+func decodeProviderData(path string, data []byte, out interface{}) error {
+	var err error
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, out)
+	case ".toml":
+		err = toml.Unmarshal(data, out)
+	default:
+		err = json.Unmarshal(data, out)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse provider config %s: %w", path, err)
+	}
+	return nil
 }
 
 // getClaudeDir returns the ~/.claude directory path
@@ -128,41 +213,361 @@ func loadSettings(path string) (*Settings, error) {
 		return nil, fmt.Errorf("failed to parse settings JSON: %w", err)
 	}
 
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err == nil {
+		warnSchemaViolations(settingsSchemaJSON, path, raw)
+	}
+
 	return &settings, nil
 }
 
-// saveSettings writes settings back to settings.json with proper JSON formatting
-func saveSettings(path string, settings *Settings) error {
+// acquireSettingsLock takes an OS-level file lock on settingsPath+".lock" to
+// guard against concurrent ccs invocations (or Claude Code itself) writing
+// settings.json at the same time. Callers must Unlock() it when done.
+func acquireSettingsLock(settingsPath string) (*flock.Flock, error) {
+	lock := flock.New(settingsPath + lockSuffix)
+	if err := lock.Lock(); err != nil {
+		return nil, fmt.Errorf("failed to lock settings file: %w", err)
+	}
+	return lock, nil
+}
+
+// backupSettings copies the current settings.json into ~/.claude/backups/
+// under a timestamped name before it's overwritten, then prunes old backups
+// beyond maxBackups. It's a no-op if settings.json doesn't exist yet.
+func backupSettings(claudeDir string, settingsPath string) error {
+	data, err := os.ReadFile(settingsPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read settings file for backup: %w", err)
+	}
+
+	dir := filepath.Join(claudeDir, backupsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backups dir: %w", err)
+	}
+
+	name := fmt.Sprintf("settings-%s.json", time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return pruneBackups(dir)
+}
+
+// pruneBackups keeps only the maxBackups most recent backups in dir.
+// Backup file names sort chronologically because they're timestamp-prefixed.
+func pruneBackups(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read backups dir: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for len(names) > maxBackups {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		names = names[1:]
+	}
+
+	return nil
+}
+
+// atomicWriteFile writes data to path via a temp file and rename, so
+// readers never observe a partially written file.
+func atomicWriteFile(path string, data []byte) error {
+	tmpPath := path + tmpSuffix
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file for %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename %s into place: %w", path, err)
+	}
+	return nil
+}
+
+// saveSettings atomically writes settings back to settings.json: it locks
+// against concurrent writers, backs up the previous contents, writes to a
+// temp file, then renames it into place.
+func saveSettings(claudeDir string, settingsPath string, settings *Settings) error {
+	lock, err := acquireSettingsLock(settingsPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := backupSettings(claudeDir, settingsPath); err != nil {
+		return err
+	}
+
 	data, err := json.MarshalIndent(settings, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file: %w", err)
+	return atomicWriteFile(settingsPath, data)
+}
+
+// envVarRefPattern matches ${VAR} and ${VAR:-default} references.
+var envVarRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ccsState is the small state file written by the normal switch path,
+// recording which provider is active so `ccs watch` knows what to re-merge.
+type ccsState struct {
+	ActiveProvider string `json:"activeProvider"`
+}
+
+// writeState atomically persists the active provider to
+// ~/.claude/.ccs-state.json. An empty provider records that nothing is active.
+func writeState(claudeDir string, provider string) error {
+	data, err := json.MarshalIndent(ccsState{ActiveProvider: provider}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return atomicWriteFile(filepath.Join(claudeDir, stateFile), data)
+}
+
+// readState reads the provider recorded by the last switch.
+func readState(claudeDir string) (*ccsState, error) {
+	data, err := os.ReadFile(filepath.Join(claudeDir, stateFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
-	return nil
+	var state ccsState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	return &state, nil
 }
 
-// loadProviderEnv reads env from ~/.claude/{provider}.json
-func loadProviderEnv(provider string, claudeDir string) (map[string]interface{}, error) {
-	providerPath := filepath.Join(claudeDir, provider+".json")
+// expandEnvVars replaces ${VAR} and ${VAR:-default} references in s with
+// values from the current process environment, falling back to the given
+// default (or the empty string) when VAR is unset.
+func expandEnvVars(s string) string {
+	return envVarRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := envVarRefPattern.FindStringSubmatch(match)
+		if val, ok := os.LookupEnv(groups[1]); ok {
+			return val
+		}
+		return groups[3]
+	})
+}
+
+// loadProviderFile reads and parses a single provider file (without
+// resolving its extends chain), expanding ${VAR}/${VAR:-default} references
+// in its env values and applying any CCS_<PROVIDER>_<KEY> overrides from the
+// process environment. envProvenance records "file" or "override" per env key.
+func loadProviderFile(provider string, claudeDir string) (*ProviderFile, map[string]string, error) {
+	providerPath, err := resolveProviderPath(provider, claudeDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	data, err := os.ReadFile(providerPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read provider config %s: %w", providerPath, err)
+		return nil, nil, fmt.Errorf("failed to read provider config %s: %w", providerPath, err)
 	}
 
-	var config EnvConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse provider config: %w", err)
+	var file ProviderFile
+	if err := decodeProviderData(providerPath, data, &file); err != nil {
+		return nil, nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := decodeProviderData(providerPath, data, &raw); err == nil {
+		warnSchemaViolations(providerSchemaJSON, providerPath, raw)
 	}
 
-	if config.Env == nil {
-		return nil, fmt.Errorf("provider config %s is missing 'env' key", providerPath)
+	envProvenance := make(map[string]string, len(file.Env))
+	for k, v := range file.Env {
+		if s, ok := v.(string); ok {
+			v = expandEnvVars(s)
+		}
+		file.Env[k] = v
+		envProvenance[k] = "file"
+	}
+
+	overridePrefix := "CCS_" + strings.ToUpper(provider) + "_"
+	for _, kv := range os.Environ() {
+		name, value, found := strings.Cut(kv, "=")
+		if !found || !strings.HasPrefix(name, overridePrefix) {
+			continue
+		}
+		if file.Env == nil {
+			file.Env = make(map[string]interface{})
+		}
+		key := strings.TrimPrefix(name, overridePrefix)
+		file.Env[key] = value
+		envProvenance[key] = "override"
 	}
 
-	return config.Env, nil
+	return &file, envProvenance, nil
+}
+
+// layer is one resolved provider file in an extends chain, tagged with the
+// provider name it came from so mergeSettings can record provenance.
+type layer struct {
+	name          string
+	file          ProviderFile
+	envProvenance map[string]string
+}
+
+// layerNames returns the ordered provider names in layers, base first.
+func layerNames(layers []layer) []string {
+	names := make([]string, len(layers))
+	for i, l := range layers {
+		names[i] = l.name
+	}
+	return names
+}
+
+// resolveProviderLayers resolves provider's `extends` chain depth-first, so
+// a base layer's values are merged before the layer(s) that extend it, and
+// returns the ordered layers to apply (base to most specific). Each provider
+// appears at most once in the result, at the position of its first visit, so
+// a diamond-shaped chain (two providers sharing a common base) merges that
+// base once instead of re-applying it after a sibling's override. Cycles in
+// the chain are rejected.
+func resolveProviderLayers(provider string, claudeDir string, visiting map[string]bool) ([]layer, error) {
+	var layers []layer
+	if err := collectProviderLayers(provider, claudeDir, visiting, make(map[string]bool), &layers); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// collectProviderLayers does the actual depth-first walk for
+// resolveProviderLayers, appending to *layers in place. seen dedupes by
+// provider name across the whole chain, independent of visiting (which only
+// tracks the current recursion stack, for cycle detection).
+func collectProviderLayers(provider string, claudeDir string, visiting map[string]bool, seen map[string]bool, layers *[]layer) error {
+	if seen[provider] {
+		return nil
+	}
+	if visiting[provider] {
+		return fmt.Errorf("cyclic provider extends chain involving %q", provider)
+	}
+	visiting[provider] = true
+	defer delete(visiting, provider)
+
+	file, envProvenance, err := loadProviderFile(provider, claudeDir)
+	if err != nil {
+		return err
+	}
+
+	for _, base := range file.Extends {
+		if err := collectProviderLayers(base, claudeDir, visiting, seen, layers); err != nil {
+			return err
+		}
+	}
+
+	seen[provider] = true
+	*layers = append(*layers, layer{name: provider, file: *file, envProvenance: envProvenance})
+	return nil
+}
+
+// deepMergeMap recursively merges src into dst: nested maps are merged key
+// by key, scalars are overwritten, and a key named "+foo" appends to
+// (rather than replaces) the array at "foo".
+func deepMergeMap(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+	for k, v := range src {
+		if base, ok := strings.CutPrefix(k, "+"); ok {
+			if arr, ok := v.([]interface{}); ok {
+				existing, _ := dst[base].([]interface{})
+				dst[base] = append(existing, arr...)
+			}
+			continue
+		}
+
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = deepMergeMap(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// mergeSettings is the general replacement for mergeEnv: it deep-merges each
+// resolved layer's env/permissions/statusLine/model onto settings, in order,
+// and returns per-key provenance (dotted as e.g. "env.ANTHROPIC_API_KEY")
+// recording which layer last contributed that key, for `ccs status`.
+func mergeSettings(settings *Settings, layers []layer) map[string]string {
+	provenance := make(map[string]string)
+
+	for _, l := range layers {
+		if len(l.file.Env) > 0 {
+			settings.Env = deepMergeMap(settings.Env, l.file.Env)
+			for k := range l.file.Env {
+				tag := l.name
+				if l.envProvenance[k] == "override" {
+					tag += ":override"
+				}
+				provenance["env."+k] = tag
+			}
+		}
+		if len(l.file.Permissions) > 0 {
+			settings.Permissions = deepMergeMap(settings.Permissions, l.file.Permissions)
+			for k := range l.file.Permissions {
+				provenance["permissions."+k] = l.name
+			}
+		}
+		if len(l.file.StatusLine) > 0 {
+			settings.StatusLine = deepMergeMap(settings.StatusLine, l.file.StatusLine)
+			for k := range l.file.StatusLine {
+				provenance["statusLine."+k] = l.name
+			}
+		}
+		if l.file.Model != "" {
+			settings.Model = l.file.Model
+			provenance["model"] = l.name
+		}
+	}
+
+	return provenance
+}
+
+// resetMergedFields clears every field mergeSettings writes to, so a fresh
+// call to mergeSettings replaces the previous provider's values instead of
+// deep-merging on top of them (stale keys/permissions/model would otherwise
+// linger across a provider switch or reload).
+func resetMergedFields(settings *Settings) {
+	settings.Env = nil
+	settings.Permissions = nil
+	settings.StatusLine = nil
+	settings.Model = ""
+}
+
+// loadProviderEnv resolves provider's extends chain and returns its fully
+// merged, effective env (without touching settings.json). A provider whose
+// chain declares no env at all (legitimate if it only carries
+// permissions/model/statusLine) comes back as a nil map, not an error.
+func loadProviderEnv(provider string, claudeDir string) (map[string]interface{}, error) {
+	layers, err := resolveProviderLayers(provider, claudeDir, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &Settings{}
+	mergeSettings(merged, layers)
+	return merged.Env, nil
 }
 
 // removeEnv removes the env key from settings
@@ -170,14 +575,462 @@ func removeEnv(settings *Settings) {
 	settings.Env = nil
 }
 
-// mergeEnv merges provider env into settings
-func mergeEnv(settings *Settings, providerEnv map[string]interface{}) {
-	if settings.Env == nil {
-		settings.Env = make(map[string]interface{})
+// ProviderInfo describes a discovered provider config for `ccs list`
+type ProviderInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Active      bool   `json:"active"`
+}
+
+// providerDescription derives a short human-readable description for a
+// provider from its env, preferring ANTHROPIC_BASE_URL since that's what
+// distinguishes most providers from the default Anthropic endpoint.
+func providerDescription(env map[string]interface{}) string {
+	if v, ok := env["ANTHROPIC_BASE_URL"]; ok {
+		return toString(v)
+	}
+	return ""
+}
+
+// listProviders scans claudeDir for provider config files (<name>.json,
+// excluding settingsFile) and reports each alongside whether its env is
+// currently the one merged into settings.json.
+func listProviders(claudeDir string, settings *Settings) ([]ProviderInfo, error) {
+	names, err := discoverProviderNames(claudeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]ProviderInfo, 0, len(names))
+	for _, name := range names {
+		providerEnv, err := loadProviderEnv(name, claudeDir)
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, ProviderInfo{
+			Name:        name,
+			Description: providerDescription(providerEnv),
+			Active:      reflect.DeepEqual(settings.Env, providerEnv),
+		})
+	}
+
+	return providers, nil
+}
+
+// cmdList implements `ccs list`
+func cmdList(args []string, claudeDir string, settings *Settings) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print providers as a JSON array")
+	fs.Parse(args)
+
+	providers, err := listProviders(claudeDir, settings)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(providers, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal providers: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(providers) == 0 {
+		fmt.Println("No providers found in " + claudeDir)
+		return nil
+	}
+
+	for _, p := range providers {
+		marker := "  "
+		if p.Active {
+			marker = "* "
+		}
+		if p.Description != "" {
+			fmt.Printf("%s%-20s %s\n", marker, p.Name, p.Description)
+		} else {
+			fmt.Printf("%s%-20s\n", marker, p.Name)
+		}
+	}
+
+	return nil
+}
+
+// cmdEnv implements `ccs env <provider>`, printing the effective merged env
+// for a provider (across its extends chain) without writing settings.json.
+func cmdEnv(args []string, claudeDir string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: ccs env <provider>")
+	}
+	provider := args[0]
+
+	layers, err := resolveProviderLayers(provider, claudeDir, make(map[string]bool))
+	if err != nil {
+		return err
+	}
+
+	merged := &Settings{}
+	provenance := mergeSettings(merged, layers)
+	if merged.Env == nil {
+		return fmt.Errorf("provider %q (and its extends chain) has no 'env' entries", provider)
+	}
+
+	keys := make([]string, 0, len(merged.Env))
+	for k := range merged.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Printf("%s=%v (%s)\n", k, merged.Env[k], provenance["env."+k])
+	}
+
+	return nil
+}
+
+// cmdStatus implements `ccs status <provider>`, resolving the provider's
+// extends chain and printing which layer contributed each effective
+// env/permissions/statusLine/model key.
+func cmdStatus(args []string, claudeDir string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: ccs status <provider>")
+	}
+	provider := args[0]
+
+	layers, err := resolveProviderLayers(provider, claudeDir, make(map[string]bool))
+	if err != nil {
+		return err
+	}
+
+	merged := &Settings{}
+	provenance := mergeSettings(merged, layers)
+
+	fmt.Printf("Layers (base to override): %s\n", strings.Join(layerNames(layers), " -> "))
+
+	keys := make([]string, 0, len(provenance))
+	for k := range provenance {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Printf("%-30s <- %s\n", k, provenance[k])
+	}
+
+	return nil
+}
+
+// cmdWatch implements `ccs watch`: it watches ~/.claude for changes to the
+// active provider's file (as recorded in the state file by the last
+// `ccs <name>` switch) and re-applies the merge into settings.json whenever
+// it's modified, debouncing rapid successive events.
+func cmdWatch(claudeDir string, settingsPath string) error {
+	state, err := readState(claudeDir)
+	if err != nil {
+		return fmt.Errorf("no active provider to watch, run `ccs <name>` first: %w", err)
+	}
+	if state.ActiveProvider == "" {
+		return fmt.Errorf("no active provider to watch, run `ccs <name>` first")
+	}
+
+	providerPath, err := resolveProviderPath(state.ActiveProvider, claudeDir)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(claudeDir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", claudeDir, err)
+	}
+
+	reapply := func() {
+		settings, err := loadSettings(settingsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading settings: %v\n", err)
+			return
+		}
+
+		layers, err := resolveProviderLayers(state.ActiveProvider, claudeDir, make(map[string]bool))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+
+		// Drop the previously merged fields before reapplying, so a reload
+		// replaces them rather than accumulating stale values.
+		resetMergedFields(settings)
+		mergeSettings(settings, layers)
+		if err := saveSettings(claudeDir, settingsPath, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving settings: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Re-applied provider %q\n", state.ActiveProvider)
+	}
+
+	fmt.Printf("Watching %s for changes to provider %q (Ctrl-C to stop)\n", providerPath, state.ActiveProvider)
+
+	var debounce *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != providerPath || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(watchDebounce, reapply)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Watch error: %v\n", err)
+		}
+	}
+}
+
+// loadProviderRaw reads a provider file into a generic map for schema
+// validation, as opposed to loadProviderFile's typed decode.
+func loadProviderRaw(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider config %s: %w", path, err)
+	}
+
+	raw := make(map[string]interface{})
+	if err := decodeProviderData(path, data, &raw); err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+// validateAgainstSchema validates doc against the given embedded JSON
+// Schema, returning one message per violation.
+func validateAgainstSchema(schema []byte, doc interface{}) ([]string, error) {
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run schema validation: %w", err)
+	}
+
+	var messages []string
+	for _, e := range result.Errors() {
+		messages = append(messages, e.String())
+	}
+	return messages, nil
+}
+
+// warnSchemaViolations runs doc (decoded from path) against schema and
+// prints any violations as warnings to stderr. It never fails the caller's
+// load: `ccs validate` is the authoritative, exit-code-bearing check, this is
+// just a heads-up on every load so broken configs aren't only caught when
+// the user remembers to run it.
+func warnSchemaViolations(schema []byte, path string, doc interface{}) {
+	messages, err := validateAgainstSchema(schema, doc)
+	if err != nil || len(messages) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s failed schema validation:\n", path)
+	for _, m := range messages {
+		fmt.Fprintf(os.Stderr, "  - %s\n", m)
+	}
+}
+
+// requiredEnvCompanions maps an env key to another key it requires, catching
+// misconfigurations a JSON Schema alone can't express (e.g. a custom base
+// URL set without the API key that goes with it).
+var requiredEnvCompanions = map[string]string{
+	"ANTHROPIC_BASE_URL": "ANTHROPIC_API_KEY",
+}
+
+// validateProviderSemantics checks a provider's effective env against
+// requiredEnvCompanions.
+func validateProviderSemantics(env map[string]interface{}) []string {
+	var messages []string
+	for key, requires := range requiredEnvCompanions {
+		if _, ok := env[key]; !ok {
+			continue
+		}
+		if _, ok := env[requires]; !ok {
+			messages = append(messages, fmt.Sprintf("env.%s is set but env.%s is missing", key, requires))
+		}
+	}
+	return messages
+}
+
+// discoverProviderNames returns the deduplicated, sorted provider names
+// found in claudeDir, for subcommands invoked without explicit names.
+func discoverProviderNames(claudeDir string) ([]string, error) {
+	entries, err := os.ReadDir(claudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claude dir %s: %w", claudeDir, err)
 	}
-	for k, v := range providerEnv {
-		settings.Env[k] = v
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == settingsFile || e.Name() == stateFile || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		ext := filepath.Ext(e.Name())
+		if !isProviderExtension(ext) {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ext)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
 	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// cmdValidate implements `ccs validate [provider...]`: it checks provider
+// files (defaulting to every discoverable one) against the embedded
+// provider JSON Schema plus requiredEnvCompanions, and checks settings.json
+// against the embedded settings schema.
+func cmdValidate(args []string, claudeDir string, settingsPath string) error {
+	providerNames := args
+	if len(providerNames) == 0 {
+		names, err := discoverProviderNames(claudeDir)
+		if err != nil {
+			return err
+		}
+		providerNames = names
+	}
+
+	ok := true
+	for _, name := range providerNames {
+		path, err := resolveProviderPath(name, claudeDir)
+		if err != nil {
+			ok = false
+			fmt.Printf("%s: %v\n", name, err)
+			continue
+		}
+
+		raw, err := loadProviderRaw(path)
+		if err != nil {
+			ok = false
+			fmt.Printf("%s: %v\n", name, err)
+			continue
+		}
+
+		messages, err := validateAgainstSchema(providerSchemaJSON, raw)
+		if err != nil {
+			return err
+		}
+
+		if layers, err := resolveProviderLayers(name, claudeDir, make(map[string]bool)); err != nil {
+			messages = append(messages, fmt.Sprintf("failed to resolve extends chain: %v", err))
+		} else {
+			merged := &Settings{}
+			mergeSettings(merged, layers)
+			messages = append(messages, validateProviderSemantics(merged.Env)...)
+		}
+
+		if len(messages) == 0 {
+			fmt.Printf("%s: ok\n", name)
+			continue
+		}
+		ok = false
+		fmt.Printf("%s:\n", name)
+		for _, m := range messages {
+			fmt.Printf("  - %s\n", m)
+		}
+	}
+
+	if raw, err := loadProviderRaw(settingsPath); err == nil {
+		messages, err := validateAgainstSchema(settingsSchemaJSON, raw)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			fmt.Println("settings.json: ok")
+		} else {
+			ok = false
+			fmt.Println("settings.json:")
+			for _, m := range messages {
+				fmt.Printf("  - %s\n", m)
+			}
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("validation failed")
+	}
+	return nil
+}
+
+// cmdRestore implements `ccs restore [--backup=<timestamp>]`, atomically
+// swapping in a prior settings.json backup.
+func cmdRestore(args []string, claudeDir string, settingsPath string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	backup := fs.String("backup", "", "timestamp (or substring) of the backup to restore; defaults to the most recent")
+	fs.Parse(args)
+
+	dir := filepath.Join(claudeDir, backupsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read backups dir %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no backups found in %s", dir)
+	}
+	sort.Strings(names)
+
+	chosen := names[len(names)-1]
+	if *backup != "" {
+		chosen = ""
+		for _, n := range names {
+			if strings.Contains(n, *backup) {
+				chosen = n
+				break
+			}
+		}
+		if chosen == "" {
+			return fmt.Errorf("no backup matching %q found in %s", *backup, dir)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, chosen))
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", chosen, err)
+	}
+
+	lock, err := acquireSettingsLock(settingsPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := atomicWriteFile(settingsPath, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored settings from backup %s\n", chosen)
+	return nil
 }
 
 // printUsage prints usage information
@@ -186,10 +1039,24 @@ func printUsage() {
 	fmt.Println("\nUsage:")
 	fmt.Println("  ccs          Reset to default (remove env key)")
 	fmt.Println("  ccs <name>   Switch to provider (merge env from <name>.json)")
+	fmt.Println("  ccs list     List available providers and mark the active one")
+	fmt.Println("  ccs env <name>  Print the effective env for a provider without writing settings")
+	fmt.Println("  ccs status <name>  Show which layer contributed each effective setting")
+	fmt.Println("  ccs restore  Restore settings.json from a backup")
+	fmt.Println("  ccs watch    Watch the active provider's file and re-apply it on change")
+	fmt.Println("  ccs validate [name...]  Validate provider configs and settings.json against their schemas")
 	fmt.Println("\nExamples:")
 	fmt.Println("  ccs glm      Switch to glm provider")
 	fmt.Println("  ccs          Reset to default")
+	fmt.Println("  ccs list --json")
+	fmt.Println("  ccs env glm")
+	fmt.Println("  ccs status glm")
+	fmt.Println("  ccs restore --backup=20260101T000000")
+	fmt.Println("  ccs watch")
+	fmt.Println("  ccs validate glm")
 	fmt.Println("\nProvider configs are located at: ~/.claude/<name>.json")
+	fmt.Println("Values support ${VAR} / ${VAR:-default} expansion and CCS_<PROVIDER>_<KEY> overrides")
+	fmt.Println("Providers may declare \"extends\": [\"base\", ...] to layer on other providers")
 }
 
 func main() {
@@ -207,6 +1074,30 @@ func main() {
 
 	settingsPath := filepath.Join(claudeDir, settingsFile)
 
+	if hasProvider && args[0] == "restore" {
+		if err := cmdRestore(args[1:], claudeDir, settingsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if hasProvider && args[0] == "watch" {
+		if err := cmdWatch(claudeDir, settingsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if hasProvider && args[0] == "validate" {
+		if err := cmdValidate(args[1:], claudeDir, settingsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load settings
 	settings, err := loadSettings(settingsPath)
 	if err != nil {
@@ -214,27 +1105,61 @@ func main() {
 		os.Exit(1)
 	}
 
+	if hasProvider && args[0] == "list" {
+		if err := cmdList(args[1:], claudeDir, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if hasProvider && args[0] == "env" {
+		if err := cmdEnv(args[1:], claudeDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if hasProvider && args[0] == "status" {
+		if err := cmdStatus(args[1:], claudeDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if hasProvider {
 		provider := args[0]
 
-		// Load provider env config
-		providerEnv, err := loadProviderEnv(provider, claudeDir)
+		// Resolve the provider's extends chain and merge every layer onto settings
+		layers, err := resolveProviderLayers(provider, claudeDir, make(map[string]bool))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Merge env into settings
-		mergeEnv(settings, providerEnv)
+		// Drop the previously active provider's fields before merging in the
+		// new one, so switching providers replaces them rather than accumulating.
+		resetMergedFields(settings)
+		mergeSettings(settings, layers)
 		fmt.Printf("Switched to provider '%s'\n", provider)
+
+		if err := writeState(claudeDir, provider); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record active provider: %v\n", err)
+		}
 	} else {
 		// Remove env key to reset to default
 		removeEnv(settings)
 		fmt.Println("Reset to default (removed env key)")
+
+		if err := writeState(claudeDir, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to clear active provider: %v\n", err)
+		}
 	}
 
 	// Save settings
-	if err := saveSettings(settingsPath, settings); err != nil {
+	if err := saveSettings(claudeDir, settingsPath, settings); err != nil {
 		fmt.Fprintf(os.Stderr, "Error saving settings: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProviderFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write provider file %s: %v", name, err)
+	}
+}
+
+// TestResolveProviderLayersDiamondExtends covers a diamond-shaped extends
+// chain: both b and c extend base, and final extends [b, c]. base must be
+// merged exactly once, before b and c, so b's override of X survives c's
+// later (non-overriding) layer instead of being clobbered by a second
+// resolution of base.
+func TestResolveProviderLayersDiamondExtends(t *testing.T) {
+	dir := t.TempDir()
+	writeProviderFile(t, dir, "base", `{"env": {"X": "base-value"}}`)
+	writeProviderFile(t, dir, "b", `{"extends": ["base"], "env": {"X": "b-value"}}`)
+	writeProviderFile(t, dir, "c", `{"extends": ["base"]}`)
+	writeProviderFile(t, dir, "final", `{"extends": ["b", "c"]}`)
+
+	layers, err := resolveProviderLayers("final", dir, make(map[string]bool))
+	if err != nil {
+		t.Fatalf("resolveProviderLayers: %v", err)
+	}
+
+	names := layerNames(layers)
+	want := []string{"base", "b", "c", "final"}
+	if len(names) != len(want) {
+		t.Fatalf("layer names = %v, want %v", names, want)
+	}
+	for i, n := range want {
+		if names[i] != n {
+			t.Fatalf("layer names = %v, want %v", names, want)
+		}
+	}
+
+	merged := &Settings{}
+	mergeSettings(merged, layers)
+	if merged.Env["X"] != "b-value" {
+		t.Errorf("merged env X = %v, want %q (b's override must survive c's shared base)", merged.Env["X"], "b-value")
+	}
+}
+
+// TestResolveProviderLayersDiamondExtendsWarnsOnce ensures a base shared by
+// two branches of a diamond extends chain is only loaded (and schema-warned)
+// once, not once per branch that reaches it.
+func TestResolveProviderLayersDiamondExtendsWarnsOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeProviderFile(t, dir, "base", `{"env": {"X": "base-value"}, "bogus": true}`)
+	writeProviderFile(t, dir, "b", `{"extends": ["base"]}`)
+	writeProviderFile(t, dir, "c", `{"extends": ["base"]}`)
+	writeProviderFile(t, dir, "final", `{"extends": ["b", "c"]}`)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	origStderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = origStderr }()
+
+	_, resolveErr := resolveProviderLayers("final", dir, make(map[string]bool))
+
+	w.Close()
+	os.Stderr = origStderr
+	captured, _ := io.ReadAll(r)
+
+	if resolveErr != nil {
+		t.Fatalf("resolveProviderLayers: %v", resolveErr)
+	}
+	if got := strings.Count(string(captured), "failed schema validation"); got != 1 {
+		t.Errorf("got %d schema validation warnings for base, want exactly 1 (shared base should be loaded once):\n%s", got, captured)
+	}
+}
+
+// TestListProvidersToleratesEnvLessProvider ensures a provider that declares
+// no env at all (only model/permissions/statusLine) doesn't make
+// listProviders fail for every provider in the directory.
+func TestListProvidersToleratesEnvLessProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeProviderFile(t, dir, "glm", `{"env": {"ANTHROPIC_BASE_URL": "https://glm"}}`)
+	writeProviderFile(t, dir, "noenv", `{"model": "claude-opus"}`)
+
+	providers, err := listProviders(dir, &Settings{})
+	if err != nil {
+		t.Fatalf("listProviders: %v", err)
+	}
+	if len(providers) != 2 {
+		t.Fatalf("listProviders returned %d providers, want 2: %+v", len(providers), providers)
+	}
+}
+
+// TestCmdValidateReportsBrokenExtends ensures a provider whose extends chain
+// references a nonexistent base is reported as a validation failure instead
+// of being silently treated as ok.
+func TestCmdValidateReportsBrokenExtends(t *testing.T) {
+	dir := t.TempDir()
+	writeProviderFile(t, dir, "broken", `{"extends": ["does-not-exist"], "env": {"ANTHROPIC_API_KEY": "x"}}`)
+
+	settingsPath := filepath.Join(dir, settingsFile)
+	if err := os.WriteFile(settingsPath, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("failed to write settings file: %v", err)
+	}
+
+	if err := cmdValidate([]string{"broken"}, dir, settingsPath); err == nil {
+		t.Fatal("cmdValidate returned nil error for a provider with a broken extends chain, want a validation failure")
+	}
+}
+
+// TestLoadProviderFileWarnsOnSchemaViolation ensures a malformed provider
+// file is flagged on every load (not just via `ccs validate`), without
+// failing the load itself.
+func TestLoadProviderFileWarnsOnSchemaViolation(t *testing.T) {
+	dir := t.TempDir()
+	writeProviderFile(t, dir, "bad", `{"env": {"ANTHROPIC_API_KEY": "x"}, "bogus": true}`)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	origStderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = origStderr }()
+
+	_, _, loadErr := loadProviderFile("bad", dir)
+
+	w.Close()
+	os.Stderr = origStderr
+	captured, _ := io.ReadAll(r)
+
+	if loadErr != nil {
+		t.Fatalf("loadProviderFile returned an error for a schema-invalid but otherwise valid file: %v", loadErr)
+	}
+	if !strings.Contains(string(captured), "failed schema validation") {
+		t.Errorf("expected a schema validation warning on stderr, got: %q", captured)
+	}
+}